@@ -0,0 +1,30 @@
+package sse
+
+import "time"
+
+// SlowClientPolicy controls what a Channel does when a client's send
+// buffer is full, i.e. when it can't keep up with the rate messages are
+// being published.
+type SlowClientPolicy int
+
+const (
+	// Drop discards the new message and counts it in
+	// Client.DroppedMessages. This is the default.
+	Drop SlowClientPolicy = iota
+	// DropOldest discards the oldest buffered message to make room for
+	// the new one.
+	DropOldest
+	// Disconnect evicts the client immediately, sending an "overflow"
+	// event first; the oldest buffered message is discarded to make room
+	// for it.
+	Disconnect
+	// Backoff retries the send after an exponentially increasing delay
+	// (starting at backoffBase, doubling on each failed attempt, capped
+	// at backoffCap) and evicts the client once the cap is reached.
+	Backoff
+)
+
+const (
+	backoffBase = 20 * time.Millisecond
+	backoffCap  = 30 * time.Second
+)
@@ -0,0 +1,46 @@
+package sse
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// BrokerMessage is the payload exchanged between go-sse instances through a
+// Broker. NodeID identifies the instance that originated the message so a
+// node can recognize and skip its own messages when they come back through
+// the broker, avoiding double delivery to its locally connected clients.
+type BrokerMessage struct {
+	NodeID  string
+	Channel string
+	Client  string
+	Message *Message
+}
+
+// Broker lets a Server fan published messages out to other go-sse
+// instances, so Server.SendMessage/SendMessageToClient reach every
+// subscribed process and not just the one that received the HTTP request.
+// Implementations only need to move BrokerMessage values between Publish
+// and the handler passed to Subscribe, so the same interface can be
+// backed by Redis, NATS, Kafka or anything else with pub/sub semantics.
+type Broker interface {
+	// Publish sends msg to every node subscribed to channel. The publishing
+	// node may receive it back too; Server skips it by NodeID.
+	Publish(channel string, msg *BrokerMessage) error
+	// Subscribe registers handler to be called for every message published
+	// to channel, including ones this node published itself if the backend
+	// echoes them. It returns a stop function that must be
+	// called to release the subscription, typically when the channel is
+	// closed locally.
+	Subscribe(channel string, handler func(*BrokerMessage)) (stop func(), err error)
+}
+
+// newNodeID returns a random identifier used to tag messages this server
+// instance publishes to a Broker, so it can ignore them when they are
+// echoed back.
+func newNodeID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%p", b)
+	}
+	return fmt.Sprintf("%x", b)
+}
@@ -0,0 +1,82 @@
+package sse
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBroker is an in-process Broker double: Subscribe registers a handler
+// per channel and deliver lets a test invoke it directly, simulating what a
+// real pub/sub backend would do when echoing a message back.
+type fakeBroker struct {
+	mu       sync.Mutex
+	handlers map[string]func(*BrokerMessage)
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{handlers: make(map[string]func(*BrokerMessage))}
+}
+
+func (b *fakeBroker) Publish(channel string, msg *BrokerMessage) error {
+	return nil
+}
+
+func (b *fakeBroker) Subscribe(channel string, handler func(*BrokerMessage)) (func(), error) {
+	b.mu.Lock()
+	b.handlers[channel] = handler
+	b.mu.Unlock()
+
+	return func() {}, nil
+}
+
+func (b *fakeBroker) deliver(channel string, msg *BrokerMessage) {
+	b.mu.Lock()
+	handler := b.handlers[channel]
+	b.mu.Unlock()
+
+	if handler != nil {
+		handler(msg)
+	}
+}
+
+// TestBrokerSkipsOwnMessages verifies that a message echoed back by the
+// Broker, tagged with this node's own NodeID, is not delivered a second
+// time to a locally connected client.
+func TestBrokerSkipsOwnMessages(t *testing.T) {
+	broker := newFakeBroker()
+	s := NewServer(context.Background(), &Options{Broker: broker})
+	defer s.Shutdown(context.Background())
+
+	c := newClient(s.ctx, "", "room", "alice", 0, 4, Drop, noopMetrics{})
+	s.addClient <- c
+	time.Sleep(10 * time.Millisecond)
+
+	s.SendMessage("room", NewMessage("1", "hello", ""))
+
+	// A real Broker would echo this straight back to us; Server must
+	// recognize its own NodeID and skip it.
+	broker.deliver("room", &BrokerMessage{
+		NodeID:  s.nodeID,
+		Channel: "room",
+		Message: NewMessage("1", "hello", ""),
+	})
+
+	time.Sleep(10 * time.Millisecond)
+
+	select {
+	case msg := <-c.send:
+		if msg.data != "hello" {
+			t.Fatalf("unexpected message delivered: %q", msg.data)
+		}
+	default:
+		t.Fatal("expected the locally published message to be delivered once")
+	}
+
+	select {
+	case msg := <-c.send:
+		t.Fatalf("message delivered twice, second delivery: %q", msg.data)
+	default:
+	}
+}
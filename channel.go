@@ -32,6 +32,32 @@ func (c *Channel) SendMessage(name string, message *Message) {
 	}
 }
 
+// snapshotTargets returns the clients message should be delivered to: just
+// the one matching name, or every client in the channel if name is "" (and,
+// for that broadcast case, records message as the channel's LastEventID).
+// It only reads the clients map and never blocks, so it's safe to call
+// while holding Server.channelsMu; callers should then release that lock
+// before actually sending to the returned clients, since that can block on
+// a slow reader and must not do so with the lock held.
+func (c *Channel) snapshotTargets(name string, message *Message) []*Client {
+	if name != "" {
+		if cl, ok := c.clients[name]; ok {
+			return []*Client{cl}
+		}
+		return nil
+	}
+
+	c.lastEventID = message.id
+
+	targets := make([]*Client, 0, len(c.clients))
+	for _, cl := range c.clients {
+		if cl != nil {
+			targets = append(targets, cl)
+		}
+	}
+	return targets
+}
+
 // Close closes the channel and disconnect all clients.
 func (c *Channel) Close() {
 	// Kick all clients of this channel.
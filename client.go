@@ -1,30 +1,270 @@
 package sse
 
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
 // Client represents a web browser connection.
 type Client struct {
+	ctx    context.Context
+	cancel context.CancelFunc
 	lastEventID,
 	channel string
 	name    string
 	send    chan *Message
+	history chan *historyReplay
 	version int
+	policy  SlowClientPolicy
+	metrics Metrics
+
+	backoffMu      sync.Mutex
+	backoffDelay   time.Duration
+	backoffQueue   []*Message
+	backoffPending bool
+
+	droppedMessages int64
+	lastBackoffMs   int64
 }
 
-func newClient(lastEventID, channel string, name string, version int) *Client {
+func newClient(parent context.Context, lastEventID, channel string, name string, version, bufferSize int, policy SlowClientPolicy, metrics Metrics) *Client {
+	ctx, cancel := context.WithCancel(parent)
+
 	return &Client{
+		ctx,
+		cancel,
 		lastEventID,
 		channel,
 		name,
-		make(chan *Message),
+		make(chan *Message, bufferSize),
+		make(chan *historyReplay, 1),
 		version,
+		policy,
+		metrics,
+		sync.Mutex{},
+		0,
+		nil,
+		false,
+		0,
+		0,
 	}
 }
 
-// SendMessage sends a message to client.
+// channelLabels is the metrics label set a Client reports its
+// Counter/Gauge/Histogram updates under.
+func (c *Client) channelLabels() map[string]string {
+	return map[string]string{"channel": c.channel}
+}
+
+// SendMessage sends a message to client. With the default unbuffered send
+// channel (Options.ClientBufferSize 0) this blocks until the client reads
+// it, same as before. With a buffered channel, a full buffer means the
+// client isn't keeping up, and c.policy decides whether the message is
+// dropped, the client is disconnected, or the send is retried with
+// exponential backoff.
 func (c *Client) SendMessage(message *Message) {
-	if message.version <= c.version {
+	if message.version > c.version {
+		return
+	}
+
+	start := time.Now()
+	defer func() {
+		c.metrics.Histogram("go_sse_send_latency_seconds", c.channelLabels()).Observe(time.Since(start).Seconds())
+	}()
+
+	if cap(c.send) == 0 {
+		if c.blockingSend(message) {
+			c.lastEventID = message.id
+			c.metrics.Counter("go_sse_messages_delivered_total", c.channelLabels()).Inc()
+		}
+		return
+	}
+
+	if c.trySend(message) {
 		c.lastEventID = message.id
-		c.send <- message
+		c.resetBackoff()
+		c.metrics.Counter("go_sse_messages_delivered_total", c.channelLabels()).Inc()
+		return
+	}
+
+	c.handleFullBuffer(message)
+}
+
+// blockingSend sends message on the legacy unbuffered path, recovering if
+// the client's send channel was concurrently closed: callers now do this
+// send without holding Server.channelsMu, so it can race a Channel.Close
+// removing this same client.
+func (c *Client) blockingSend(message *Message) (sent bool) {
+	defer func() {
+		if recover() != nil {
+			sent = false
+		}
+	}()
+
+	c.send <- message
+	return true
+}
+
+// trySend attempts a non-blocking send of message, returning false if the
+// buffer is full or the client has already been evicted and its send
+// channel closed.
+func (c *Client) trySend(message *Message) (sent bool) {
+	defer func() {
+		if recover() != nil {
+			sent = false
+		}
+	}()
+
+	select {
+	case c.send <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+// handleFullBuffer applies c.policy when a non-blocking send of message
+// found the buffer full.
+func (c *Client) handleFullBuffer(message *Message) {
+	switch c.policy {
+	case DropOldest:
+		select {
+		case <-c.send:
+			c.dropMessage()
+		default:
+		}
+
+		if c.trySend(message) {
+			c.lastEventID = message.id
+			c.metrics.Counter("go_sse_messages_delivered_total", c.channelLabels()).Inc()
+		} else {
+			c.dropMessage()
+		}
+
+	case Disconnect:
+		c.dropMessage()
+		c.evict()
+
+	case Backoff:
+		c.enqueueBackoff(message)
+
+	default: // Drop
+		c.dropMessage()
+	}
+}
+
+// dropMessage counts a message discarded for this client.
+func (c *Client) dropMessage() {
+	atomic.AddInt64(&c.droppedMessages, 1)
+	c.metrics.Counter("go_sse_messages_dropped_total", c.channelLabels()).Inc()
+}
+
+// enqueueBackoff appends message to the client's pending backoff queue.
+// Messages are retried strictly in the order they were enqueued, so a
+// single flush loop (started here if one isn't already running) is the
+// only goroutine allowed to retry sends; otherwise independent per-message
+// timers could deliver messages out of order.
+func (c *Client) enqueueBackoff(message *Message) {
+	c.backoffMu.Lock()
+	c.backoffQueue = append(c.backoffQueue, message)
+	alreadyPending := c.backoffPending
+	c.backoffPending = true
+	c.backoffMu.Unlock()
+
+	if !alreadyPending {
+		c.scheduleBackoffRetry()
+	}
+}
+
+// scheduleBackoffRetry schedules the next attempt to flush the backoff
+// queue after an exponentially increasing delay, evicting the client
+// (and dropping everything still queued) once backoffCap is reached.
+func (c *Client) scheduleBackoffRetry() {
+	c.backoffMu.Lock()
+	if c.backoffDelay == 0 {
+		c.backoffDelay = backoffBase
+	} else {
+		c.backoffDelay *= 2
+	}
+	delay := c.backoffDelay
+	c.backoffMu.Unlock()
+
+	atomic.StoreInt64(&c.lastBackoffMs, delay.Milliseconds())
+
+	if delay > backoffCap {
+		c.backoffMu.Lock()
+		queued := c.backoffQueue
+		c.backoffQueue = nil
+		c.backoffPending = false
+		c.backoffDelay = 0
+		c.backoffMu.Unlock()
+		atomic.StoreInt64(&c.lastBackoffMs, 0)
+
+		for range queued {
+			c.dropMessage()
+		}
+		c.evict()
+		return
+	}
+
+	time.AfterFunc(delay, c.flushBackoffQueue)
+}
+
+// flushBackoffQueue retries the head of the backoff queue, in order,
+// for as long as sends keep succeeding, then either drains the queue or
+// reschedules another delayed retry for whatever's left.
+func (c *Client) flushBackoffQueue() {
+	for {
+		c.backoffMu.Lock()
+		if len(c.backoffQueue) == 0 {
+			c.backoffPending = false
+			c.backoffMu.Unlock()
+			return
+		}
+		next := c.backoffQueue[0]
+		c.backoffMu.Unlock()
+
+		if !c.trySend(next) {
+			c.scheduleBackoffRetry()
+			return
+		}
+
+		c.lastEventID = next.id
+		c.resetBackoff()
+		c.metrics.Counter("go_sse_messages_delivered_total", c.channelLabels()).Inc()
+
+		c.backoffMu.Lock()
+		c.backoffQueue = c.backoffQueue[1:]
+		c.backoffMu.Unlock()
+	}
+}
+
+// resetBackoff clears the backoff delay after a successful send.
+func (c *Client) resetBackoff() {
+	c.backoffMu.Lock()
+	c.backoffDelay = 0
+	c.backoffMu.Unlock()
+
+	atomic.StoreInt64(&c.lastBackoffMs, 0)
+}
+
+// evict notifies the client it is being dropped for falling too far
+// behind, then cancels its context so ServeHTTP tears down the connection.
+// evict is only ever reached because c.send is already full (Disconnect
+// policy, or Backoff once backoffCap is hit), so a plain trySend of the
+// overflow notice would fail silently in the common case; a slot is freed
+// first, the same way DropOldest does, so the client actually has a
+// chance to see it and resync.
+func (c *Client) evict() {
+	select {
+	case <-c.send:
+		c.dropMessage()
+	default:
 	}
+	c.trySend(&Message{event: "overflow"})
+	c.cancel()
 }
 
 // Channel returns the channel where this client is subscribe to.
@@ -36,3 +276,16 @@ func (c *Client) Channel() string {
 func (c *Client) LastEventID() string {
 	return c.lastEventID
 }
+
+// DroppedMessages returns the number of messages dropped for this client
+// because its send buffer was full.
+func (c *Client) DroppedMessages() int64 {
+	return atomic.LoadInt64(&c.droppedMessages)
+}
+
+// LastBackoffMs returns the delay, in milliseconds, of the most recent
+// backoff retry scheduled for this client under SlowClientPolicy Backoff.
+// It is 0 when the client has no pending backoff.
+func (c *Client) LastBackoffMs() int64 {
+	return atomic.LoadInt64(&c.lastBackoffMs)
+}
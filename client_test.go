@@ -0,0 +1,79 @@
+package sse
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestBackoffPreservesOrder fills a client's buffer, forcing every
+// subsequent send through SlowClientPolicy Backoff, then drains the
+// buffer slowly and checks messages arrive in the order they were sent.
+// Independent per-message retry timers could otherwise race and deliver
+// them out of order.
+func TestBackoffPreservesOrder(t *testing.T) {
+	c := newClient(context.Background(), "", "room", "alice", 0, 1, Backoff, noopMetrics{})
+
+	const n = 5
+	for i := 0; i < n; i++ {
+		c.SendMessage(NewMessage("", string(rune('a'+i)), ""))
+	}
+
+	var got []string
+	deadline := time.After(2 * time.Second)
+	for len(got) < n {
+		select {
+		case msg := <-c.send:
+			got = append(got, msg.data)
+		case <-deadline:
+			t.Fatalf("timed out waiting for messages, got %v so far", got)
+		}
+	}
+
+	for i, data := range got {
+		want := string(rune('a' + i))
+		if data != want {
+			t.Fatalf("message %d out of order: got %q, want %q (full sequence: %v)", i, data, want, got)
+		}
+	}
+}
+
+// TestLastEventIDOnlyAdvancesOnDelivery verifies LastEventID isn't
+// updated for a message that was dropped rather than delivered.
+func TestLastEventIDOnlyAdvancesOnDelivery(t *testing.T) {
+	c := newClient(context.Background(), "", "room", "bob", 0, 1, Drop, noopMetrics{})
+
+	c.SendMessage(NewMessage("1", "first", ""))
+	<-c.send // drain so the buffer has room for the next send
+
+	c.SendMessage(NewMessage("2", "second", ""))
+	c.SendMessage(NewMessage("3", "third", "")) // buffer full: dropped under Drop
+
+	if got := c.LastEventID(); got != "2" {
+		t.Fatalf("LastEventID() = %q, want %q (dropped message 3 must not advance it)", got, "2")
+	}
+}
+
+// TestEvictDeliversOverflowNotice verifies evict's "overflow" notice
+// actually reaches the client instead of silently failing: evict is only
+// ever called because the buffer is already full, so a plain non-blocking
+// send of the notice would find no room and be dropped.
+func TestEvictDeliversOverflowNotice(t *testing.T) {
+	c := newClient(context.Background(), "", "room", "bob", 0, 1, Disconnect, noopMetrics{})
+
+	c.SendMessage(NewMessage("1", "first", ""))  // fills the buffer
+	c.SendMessage(NewMessage("2", "second", "")) // buffer full: evicted under Disconnect
+
+	select {
+	case msg := <-c.send:
+		if msg.event != "overflow" {
+			t.Fatalf("expected the buffered message to be the overflow notice, got event %q", msg.event)
+		}
+	default:
+		t.Fatal("expected evict's overflow notice to have been delivered")
+	}
+
+	if c.ctx.Err() == nil {
+		t.Fatal("expected evict to cancel the client's context")
+	}
+}
@@ -0,0 +1,18 @@
+package sse
+
+import "encoding/json"
+
+// Codec marshals arbitrary values into the bytes that become a message's
+// data. The default, used by Server.Publish when Options.Codec is nil, is
+// encoding/json; implement Codec to plug in protobuf, msgpack, CBOR or any
+// other framing so payload serialization stays separate from it.
+type Codec interface {
+	Encode(v interface{}) ([]byte, error)
+}
+
+// jsonCodec is the default Codec.
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
@@ -0,0 +1,113 @@
+package sse
+
+import (
+	"sync"
+	"time"
+)
+
+// HistoryStore persists recently published messages per channel so that a
+// client reconnecting with Last-Event-ID can replay everything it missed
+// instead of silently losing it. The default implementation used by
+// NewServer is an in-memory ring buffer; a Redis Streams or SQLite backed
+// store can be plugged in through Options.HistoryStore for multi-instance
+// deployments.
+type HistoryStore interface {
+	// Add appends message to the history kept for channel. Messages
+	// without an id are ignored, since there is nothing for a future
+	// Last-Event-ID lookup to match against.
+	Add(channel string, message *Message)
+	// Since returns every message stored for channel after the one with
+	// the given id, oldest first. found is false when id is not present,
+	// for example because it was evicted or expired.
+	Since(channel, id string) (messages []*Message, found bool)
+}
+
+// historyReplay is handed to a newly registered Client over its history
+// channel once dispatch has looked up its Last-Event-ID history. Doing
+// that lookup as part of registration, under the same lock recordHistory
+// uses, means no publish can land in the gap between the two and be
+// either missed (not in the replay, not yet registered to receive it
+// live) or delivered twice (in the replay and also live).
+type historyReplay struct {
+	messages []*Message
+	found    bool
+}
+
+type historyEntry struct {
+	message  *Message
+	storedAt time.Time
+}
+
+// memoryHistoryStore is the default HistoryStore: a bounded, TTL-pruned
+// ring buffer kept per channel in process memory.
+type memoryHistoryStore struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string][]historyEntry
+}
+
+func newMemoryHistoryStore(size int, ttl time.Duration) *memoryHistoryStore {
+	return &memoryHistoryStore{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string][]historyEntry),
+	}
+}
+
+func (s *memoryHistoryStore) Add(channel string, message *Message) {
+	if message.id == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := append(s.prune(channel), historyEntry{message, time.Now()})
+	if len(entries) > s.size {
+		entries = entries[len(entries)-s.size:]
+	}
+	s.entries[channel] = entries
+}
+
+func (s *memoryHistoryStore) Since(channel, id string) ([]*Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := s.prune(channel)
+	for i, entry := range entries {
+		if entry.message.id != id {
+			continue
+		}
+
+		rest := entries[i+1:]
+		messages := make([]*Message, len(rest))
+		for j, e := range rest {
+			messages[j] = e.message
+		}
+		return messages, true
+	}
+
+	return nil, false
+}
+
+// prune drops entries older than the configured TTL. Callers must hold s.mu.
+func (s *memoryHistoryStore) prune(channel string) []historyEntry {
+	entries := s.entries[channel]
+	if s.ttl <= 0 || len(entries) == 0 {
+		return entries
+	}
+
+	cutoff := time.Now().Add(-s.ttl)
+	i := 0
+	for i < len(entries) && entries[i].storedAt.Before(cutoff) {
+		i++
+	}
+
+	if i > 0 {
+		entries = entries[i:]
+		s.entries[channel] = entries
+	}
+
+	return entries
+}
@@ -0,0 +1,59 @@
+package sse
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// TestReplayDoesNotMissConcurrentPublish reproduces the gap review comment
+// #4 described: a client reconnecting with Last-Event-ID used to replay
+// history, via a direct HistoryStore.Since call, strictly before
+// registering with dispatch. A publish landing in between was in neither
+// the replay nor delivered live. dispatch now computes the replay itself
+// as part of registration, under the same lock recordHistory uses, so
+// every publish is accounted for exactly once.
+func TestReplayDoesNotMissConcurrentPublish(t *testing.T) {
+	store := newMemoryHistoryStore(10, 0)
+	s := NewServer(context.Background(), &Options{HistoryStore: store})
+	defer s.Shutdown(context.Background())
+
+	store.Add("room", NewMessage("1", "first", "greeting"))
+
+	c := newClient(s.ctx, "1", "room", "bob", 0, 1, Drop, noopMetrics{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.addClient <- c
+	}()
+
+	// Race a publish against the registration above; whichever wins, the
+	// message must end up either in the replay or delivered live, never
+	// in neither.
+	s.Publish("room", "greeting", "second")
+	wg.Wait()
+
+	var fromReplay []*Message
+	replay := <-c.history
+	if replay.found {
+		fromReplay = replay.messages
+	}
+
+	var fromLive []*Message
+drain:
+	for {
+		select {
+		case msg := <-c.send:
+			fromLive = append(fromLive, msg)
+		default:
+			break drain
+		}
+	}
+
+	total := len(fromReplay) + len(fromLive)
+	if total != 1 {
+		t.Fatalf("expected the second publish to be replayed or delivered live exactly once, got %d total messages (replay=%d live=%d)", total, len(fromReplay), len(fromLive))
+	}
+}
@@ -0,0 +1,69 @@
+package sse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryHistoryStoreSinceReplaysInOrder(t *testing.T) {
+	store := newMemoryHistoryStore(10, 0)
+
+	store.Add("room", NewMessage("1", "a", ""))
+	store.Add("room", NewMessage("2", "b", ""))
+	store.Add("room", NewMessage("3", "c", ""))
+
+	messages, found := store.Since("room", "1")
+	if !found {
+		t.Fatal("expected id 1 to be found")
+	}
+	if len(messages) != 2 || messages[0].data != "b" || messages[1].data != "c" {
+		t.Fatalf("unexpected replay: %+v", messages)
+	}
+
+	if _, found := store.Since("room", "missing"); found {
+		t.Fatal("expected unknown id to report not found")
+	}
+}
+
+func TestMemoryHistoryStoreEvictsBeyondSize(t *testing.T) {
+	store := newMemoryHistoryStore(2, 0)
+
+	store.Add("room", NewMessage("1", "a", ""))
+	store.Add("room", NewMessage("2", "b", ""))
+	store.Add("room", NewMessage("3", "c", ""))
+
+	if _, found := store.Since("room", "1"); found {
+		t.Fatal("expected the oldest entry to have been evicted once size was exceeded")
+	}
+
+	messages, found := store.Since("room", "2")
+	if !found || len(messages) != 1 || messages[0].data != "c" {
+		t.Fatalf("unexpected replay after eviction: found=%v messages=%+v", found, messages)
+	}
+}
+
+func TestMemoryHistoryStorePrunesByTTL(t *testing.T) {
+	store := newMemoryHistoryStore(10, 20*time.Millisecond)
+
+	store.Add("room", NewMessage("1", "a", ""))
+	time.Sleep(30 * time.Millisecond)
+	store.Add("room", NewMessage("2", "b", ""))
+
+	if _, found := store.Since("room", "1"); found {
+		t.Fatal("expected the expired entry to have been pruned")
+	}
+
+	if _, found := store.Since("room", "2"); !found {
+		t.Fatal("expected the fresh entry to still be present")
+	}
+}
+
+func TestMemoryHistoryStoreIgnoresEmptyID(t *testing.T) {
+	store := newMemoryHistoryStore(10, 0)
+
+	store.Add("room", NewMessage("", "no-id", ""))
+
+	if len(store.entries["room"]) != 0 {
+		t.Fatal("expected a message with no id to be ignored")
+	}
+}
@@ -2,6 +2,7 @@ package sse
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"strings"
 )
@@ -43,6 +44,43 @@ func NewMessageVer(id, data, event string, version int) *Message {
 	}
 }
 
+// NewJSONMessage creates a Message whose data is the JSON encoding of v,
+// so callers don't have to marshal and stringify it themselves. id and
+// event are used as-is, same as NewMessage. json.Marshal escapes newlines
+// inside strings and emits none of its own, so the data always fits on a
+// single "data:" line.
+func NewJSONMessage(id, event string, v interface{}) (*Message, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMessage(id, string(data), event), nil
+}
+
+// ID returns the message's event id, as set through NewMessage/NewMessageVer
+// or NewJSONMessage.
+func (m *Message) ID() string {
+	return m.id
+}
+
+// Data returns the message's payload.
+func (m *Message) Data() string {
+	return m.data
+}
+
+// Event returns the message's event name, or "" for the default message
+// event.
+func (m *Message) Event() string {
+	return m.event
+}
+
+// Version returns the version a client must be at or above to receive this
+// message. See NewMessageVer.
+func (m *Message) Version() int {
+	return m.version
+}
+
 func (m *Message) String() string {
 	var buffer bytes.Buffer
 
@@ -0,0 +1,42 @@
+package sse
+
+import "testing"
+
+func TestMessageAccessors(t *testing.T) {
+	msg := NewMessageVer("42", "payload", "update", 3)
+
+	if got := msg.ID(); got != "42" {
+		t.Fatalf("ID() = %q, want %q", got, "42")
+	}
+	if got := msg.Data(); got != "payload" {
+		t.Fatalf("Data() = %q, want %q", got, "payload")
+	}
+	if got := msg.Event(); got != "update" {
+		t.Fatalf("Event() = %q, want %q", got, "update")
+	}
+	if got := msg.Version(); got != 3 {
+		t.Fatalf("Version() = %d, want %d", got, 3)
+	}
+}
+
+func TestNewJSONMessage(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+
+	msg, err := NewJSONMessage("1", "created", payload{Name: "alice"})
+	if err != nil {
+		t.Fatalf("NewJSONMessage: %v", err)
+	}
+
+	want := `{"name":"alice"}`
+	if got := msg.Data(); got != want {
+		t.Fatalf("Data() = %q, want %q", got, want)
+	}
+	if got := msg.ID(); got != "1" {
+		t.Fatalf("ID() = %q, want %q", got, "1")
+	}
+	if got := msg.Event(); got != "created" {
+		t.Fatalf("Event() = %q, want %q", got, "created")
+	}
+}
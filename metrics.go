@@ -0,0 +1,62 @@
+package sse
+
+// Counter is a monotonically increasing value, e.g. messages published.
+type Counter interface {
+	Inc()
+	Add(delta float64)
+}
+
+// Gauge is a value that can go up or down, e.g. clients connected.
+type Gauge interface {
+	Set(value float64)
+	Inc()
+	Dec()
+}
+
+// Histogram samples observations into buckets, e.g. send latency.
+type Histogram interface {
+	Observe(value float64)
+}
+
+// Metrics is a minimal metrics facade: a Server asks it for named, labeled
+// Counter/Gauge/Histogram handles and updates them as it connects clients,
+// publishes messages and manages channels. A ready-to-use implementation
+// backed by the Prometheus client library is provided by the
+// prometheusmetrics subpackage.
+type Metrics interface {
+	Counter(name string, labels map[string]string) Counter
+	Gauge(name string, labels map[string]string) Gauge
+	Histogram(name string, labels map[string]string) Histogram
+}
+
+// noopMetrics is used when Options.Metrics is nil, so call sites never
+// have to nil-check.
+type noopMetrics struct{}
+
+func (noopMetrics) Counter(string, map[string]string) Counter     { return noopCounter{} }
+func (noopMetrics) Gauge(string, map[string]string) Gauge         { return noopGauge{} }
+func (noopMetrics) Histogram(string, map[string]string) Histogram { return noopHistogram{} }
+
+type noopCounter struct{}
+
+func (noopCounter) Inc()        {}
+func (noopCounter) Add(float64) {}
+
+type noopGauge struct{}
+
+func (noopGauge) Set(float64) {}
+func (noopGauge) Inc()        {}
+func (noopGauge) Dec()        {}
+
+type noopHistogram struct{}
+
+func (noopHistogram) Observe(float64) {}
+
+// metrics returns s.options.Metrics, or a no-op implementation if unset.
+func (s *Server) metrics() Metrics {
+	if s.options.Metrics == nil {
+		return noopMetrics{}
+	}
+
+	return s.options.Metrics
+}
@@ -2,6 +2,7 @@ package sse
 
 import (
 	"net/http"
+	"time"
 )
 
 // LoggerInterface - Logger in Options should implement it
@@ -19,12 +20,56 @@ type Options struct {
 	// ChannelNameFunc allow to create custom channel names.
 	// Default channel name is the request path.
 	ChannelNameFunc func(*http.Request) (chName string, clientName string, version int)
-	// OnCloseChannelFunc will be called on channel close if set
-	OnClientDisconnectFunc func(chName string, clientName string)
+	// OnClientConnect is called when a client connects to a channel.
+	OnClientConnect func(chName string, clientName string)
+	// OnClientDisconnect is called when a client disconnects from a channel.
+	OnClientDisconnect func(chName string, clientName string)
+	// OnChannelCreate is called when a channel is created, i.e. when its
+	// first client connects.
+	OnChannelCreate func(chName string)
+	// OnChannelClose is called when a channel is closed, either because
+	// its last client disconnected or CloseChannel/Shutdown was called.
+	OnChannelClose func(chName string)
+	// OnMessagePublish is called for every channel a message is published
+	// to, after it has been handed to that channel's local clients (if any).
+	OnMessagePublish func(chName string, message *Message)
 	// All usage logs end up in Logger
 	Logger LoggerInterface
 	// Send heartbeat message every 15 seconds
 	Heartbeat bool
+	// Broker, when set, fans out published messages to other go-sse
+	// instances (e.g. backed by Redis) so horizontally scaled deployments
+	// stay in sync. Clients connected to any instance receive messages
+	// published on any other instance.
+	Broker Broker
+	// HistorySize is the number of past messages kept per channel so a
+	// reconnecting client sending Last-Event-ID can replay what it
+	// missed. A value of 0 (the default) disables history.
+	HistorySize int
+	// HistoryTTL discards history entries older than this duration,
+	// regardless of HistorySize. Zero means entries never expire by age.
+	HistoryTTL time.Duration
+	// HistoryStore overrides the default in-memory ring buffer used for
+	// Last-Event-ID replay, e.g. to back it with Redis Streams or SQLite
+	// for multi-instance deployments. Leave nil to use the default,
+	// sized by HistorySize and HistoryTTL.
+	HistoryStore HistoryStore
+	// ClientBufferSize sets how many messages are buffered per client
+	// before SlowClientPolicy kicks in. A value of 0 (the default) keeps
+	// the send channel unbuffered, so a publish blocks until the client
+	// reads it.
+	ClientBufferSize int
+	// SlowClientPolicy decides what happens to a client whose send buffer
+	// is full. Only relevant when ClientBufferSize > 0. Defaults to Drop.
+	SlowClientPolicy SlowClientPolicy
+	// Codec marshals the values passed to Server.Publish. Leave nil to
+	// use encoding/json.
+	Codec Codec
+	// Metrics, when set, receives connected-clients/published/delivered/
+	// dropped/heartbeat/channel-lifecycle metrics. A ready-to-use
+	// Prometheus-backed implementation is provided by the
+	// prometheusmetrics subpackage.
+	Metrics Metrics
 }
 
 func (opt *Options) hasHeaders() bool {
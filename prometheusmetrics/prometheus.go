@@ -0,0 +1,160 @@
+// Package prometheusmetrics implements sse.Metrics on top of the
+// Prometheus client library, so a go-sse Server's observability can be
+// scraped like any other Prometheus target.
+package prometheusmetrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	sse "github.com/vc2402/go-sse"
+)
+
+// Metrics is a ready-to-use sse.Metrics, also implementing
+// prometheus.Collector so it can be passed to prometheus.MustRegister. It
+// exposes clients connected (gauge, per channel), messages published,
+// delivered and dropped (counters, per channel), send latency (histogram,
+// per channel), heartbeats sent and channel create/close events
+// (counters).
+type Metrics struct {
+	counters   map[string]*prometheus.CounterVec
+	gauges     map[string]*prometheus.GaugeVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+// New creates a Metrics with namespace prepended to every metric name.
+func New(namespace string) *Metrics {
+	return &Metrics{
+		counters: map[string]*prometheus.CounterVec{
+			"go_sse_messages_published_total": prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "messages_published_total",
+				Help:      "Messages handed to SendMessage, SendMessageToClient or Publish.",
+			}, []string{"channel"}),
+			"go_sse_messages_delivered_total": prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "messages_delivered_total",
+				Help:      "Messages successfully written to a client's send buffer.",
+			}, []string{"channel"}),
+			"go_sse_messages_dropped_total": prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "messages_dropped_total",
+				Help:      "Messages discarded by a SlowClientPolicy.",
+			}, []string{"channel"}),
+			"go_sse_heartbeats_total": prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "heartbeats_total",
+				Help:      "Heartbeat messages broadcast.",
+			}, nil),
+			"go_sse_channels_created_total": prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "channels_created_total",
+				Help:      "Channels created.",
+			}, nil),
+			"go_sse_channels_closed_total": prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "channels_closed_total",
+				Help:      "Channels closed.",
+			}, nil),
+		},
+		gauges: map[string]*prometheus.GaugeVec{
+			"go_sse_clients_connected": prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "clients_connected",
+				Help:      "Clients currently connected, per channel.",
+			}, []string{"channel"}),
+		},
+		histograms: map[string]*prometheus.HistogramVec{
+			"go_sse_send_latency_seconds": prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "send_latency_seconds",
+				Help:      "Time spent sending a message to a client's buffer.",
+			}, []string{"channel"}),
+		},
+	}
+}
+
+// labelValues extracts the single "channel" label value sse.Server passes,
+// in the order CounterVec/GaugeVec/HistogramVec were declared with above.
+func labelValues(labels map[string]string) []string {
+	if v, ok := labels["channel"]; ok {
+		return []string{v}
+	}
+
+	return nil
+}
+
+// Counter implements sse.Metrics. name is expected to be one declared by
+// New; an unknown name returns a no-op rather than panicking, since a
+// label-cardinality mismatch would otherwise crash the caller.
+func (m *Metrics) Counter(name string, labels map[string]string) sse.Counter {
+	vec, ok := m.counters[name]
+	if !ok {
+		return noopCounter{}
+	}
+
+	return vec.WithLabelValues(labelValues(labels)...)
+}
+
+// Gauge implements sse.Metrics.
+func (m *Metrics) Gauge(name string, labels map[string]string) sse.Gauge {
+	vec, ok := m.gauges[name]
+	if !ok {
+		return noopGauge{}
+	}
+
+	return vec.WithLabelValues(labelValues(labels)...)
+}
+
+// Histogram implements sse.Metrics.
+func (m *Metrics) Histogram(name string, labels map[string]string) sse.Histogram {
+	vec, ok := m.histograms[name]
+	if !ok {
+		return noopHistogram{}
+	}
+
+	return vec.WithLabelValues(labelValues(labels)...)
+}
+
+type noopCounter struct{}
+
+func (noopCounter) Inc()        {}
+func (noopCounter) Add(float64) {}
+
+type noopGauge struct{}
+
+func (noopGauge) Set(float64) {}
+func (noopGauge) Inc()        {}
+func (noopGauge) Dec()        {}
+
+type noopHistogram struct{}
+
+func (noopHistogram) Observe(float64) {}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+func (m *Metrics) collectors() []prometheus.Collector {
+	collectors := make([]prometheus.Collector, 0, len(m.counters)+len(m.gauges)+len(m.histograms))
+
+	for _, c := range m.counters {
+		collectors = append(collectors, c)
+	}
+	for _, g := range m.gauges {
+		collectors = append(collectors, g)
+	}
+	for _, h := range m.histograms {
+		collectors = append(collectors, h)
+	}
+
+	return collectors
+}
@@ -0,0 +1,47 @@
+package sse
+
+import (
+	"context"
+	"testing"
+)
+
+// TestPublishRecordsHistory verifies Publish generates an id for the
+// message it sends, so it's actually captured by HistoryStore instead of
+// being silently dropped (Add ignores empty-id messages).
+func TestPublishRecordsHistory(t *testing.T) {
+	store := newMemoryHistoryStore(10, 0)
+	s := NewServer(context.Background(), &Options{HistoryStore: store})
+	defer s.Shutdown(context.Background())
+
+	if err := s.Publish("room", "greeting", "hello"); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if _, found := store.Since("room", "does-not-exist"); found {
+		t.Fatal("Since unexpectedly found an unrelated id")
+	}
+
+	entries := store.entries["room"]
+	if len(entries) != 1 {
+		t.Fatalf("expected Publish to record 1 history entry, got %d", len(entries))
+	}
+	if entries[0].message.id == "" {
+		t.Fatal("Publish recorded a message with an empty id")
+	}
+}
+
+// TestSendMessageToClientRecordsHistoryWithNoClients verifies publishing to
+// a channel with no locally connected clients still records history: the
+// whole point of a HistoryStore is to replay what a reconnecting client
+// missed, including messages published while nobody was subscribed.
+func TestSendMessageToClientRecordsHistoryWithNoClients(t *testing.T) {
+	store := newMemoryHistoryStore(10, 0)
+	s := NewServer(context.Background(), &Options{HistoryStore: store})
+	defer s.Shutdown(context.Background())
+
+	s.SendMessageToClient("empty-room", "", NewMessage("1", "hello", "greeting"))
+
+	if len(store.entries["empty-room"]) != 1 {
+		t.Fatalf("expected history to record 1 entry, got %d", len(store.entries["empty-room"]))
+	}
+}
@@ -0,0 +1,109 @@
+// Package redisbroker implements sse.Broker on top of Redis PUBSUB, so a
+// go-sse Server can fan messages out across multiple instances.
+package redisbroker
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v8"
+	sse "github.com/vc2402/go-sse"
+)
+
+// Broker publishes and subscribes to go-sse channels through Redis PUBSUB.
+// Each go-sse channel maps 1:1 onto a Redis channel named Prefix+channel,
+// so channel lifecycle (create/close) is mirrored by subscribing and
+// unsubscribing from Redis rather than by any state kept in Broker itself.
+type Broker struct {
+	client *redis.Client
+	prefix string
+}
+
+// New creates a Broker that publishes and subscribes through client.
+// prefix is prepended to every go-sse channel name to build the Redis
+// channel name, so a single Redis instance can be shared by unrelated
+// go-sse deployments.
+func New(client *redis.Client, prefix string) *Broker {
+	return &Broker{client: client, prefix: prefix}
+}
+
+func (b *Broker) redisChannel(channel string) string {
+	return b.prefix + channel
+}
+
+// wireMessage mirrors the fields of sse.Message that matter for replaying a
+// broker message on another node. sse.Message keeps those fields
+// unexported, so it can't be marshaled directly: encoding/json would see no
+// exported fields and silently emit "{}".
+type wireMessage struct {
+	ID      string
+	Data    string
+	Event   string
+	Version int
+}
+
+// wireBrokerMessage is the JSON wire format for sse.BrokerMessage.
+type wireBrokerMessage struct {
+	NodeID  string
+	Channel string
+	Client  string
+	Message wireMessage
+}
+
+// Publish implements sse.Broker.
+func (b *Broker) Publish(channel string, msg *sse.BrokerMessage) error {
+	payload, err := json.Marshal(wireBrokerMessage{
+		NodeID:  msg.NodeID,
+		Channel: msg.Channel,
+		Client:  msg.Client,
+		Message: wireMessage{
+			ID:      msg.Message.ID(),
+			Data:    msg.Message.Data(),
+			Event:   msg.Message.Event(),
+			Version: msg.Message.Version(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return b.client.Publish(context.Background(), b.redisChannel(channel), payload).Err()
+}
+
+// Subscribe implements sse.Broker.
+func (b *Broker) Subscribe(channel string, handler func(*sse.BrokerMessage)) (func(), error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pubsub := b.client.Subscribe(ctx, b.redisChannel(channel))
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case m, ok := <-pubsub.Channel():
+				if !ok {
+					return
+				}
+
+				var msg wireBrokerMessage
+				if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil {
+					continue
+				}
+
+				handler(&sse.BrokerMessage{
+					NodeID:  msg.NodeID,
+					Channel: msg.Channel,
+					Client:  msg.Client,
+					Message: sse.NewMessageVer(msg.Message.ID, msg.Message.Data, msg.Message.Event, msg.Message.Version),
+				})
+			}
+		}
+	}()
+
+	stop := func() {
+		cancel()
+		pubsub.Close()
+	}
+
+	return stop, nil
+}
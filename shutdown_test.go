@@ -0,0 +1,41 @@
+package sse
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestShutdownDeadlineDoesNotRaceRemoveClient exercises the path where
+// Shutdown's context expires before a client has actually drained: the
+// fix must wait for that client before closing the channels dispatch
+// selects on, or a concurrent removeClient send could race a closed
+// channel and panic.
+func TestShutdownDeadlineDoesNotRaceRemoveClient(t *testing.T) {
+	s := NewServer(context.Background(), &Options{})
+
+	c := newClient(s.ctx, "", "room", "bob", 0, 0, Drop, noopMetrics{})
+	s.addClient <- c
+
+	// Mimic the ServeHTTP goroutine: wg is held until the client has
+	// actually been removed, and that removal is deliberately slow, so
+	// the Shutdown deadline below fires first.
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		<-c.ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		s.removeClient <- c
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	if err := s.Shutdown(ctx); err == nil {
+		t.Fatal("expected Shutdown to return the expired context's error")
+	}
+
+	// Give the deferred drain time to finish; previously this could panic
+	// with "send on closed channel" from the removeClient send above.
+	time.Sleep(100 * time.Millisecond)
+}
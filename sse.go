@@ -1,26 +1,47 @@
 package sse
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Server represents a server sent events server.
 type Server struct {
-	options      *Options
-	channels     map[string]*Channel
-	addClient    chan *Client
-	removeClient chan *Client
-	shutdown     chan bool
-	closeChannel chan string
+	options       *Options
+	channels      map[string]*Channel
+	channelsMu    sync.RWMutex
+	addClient     chan *Client
+	removeClient  chan *Client
+	shutdown      chan bool
+	closeChannel  chan string
+	nodeID        string
+	brokerStop    map[string]func()
+	remoteMessage chan *BrokerMessage
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+	closeOnce     sync.Once
+	publishSeq    int64
+	shutdownMu    sync.Mutex
+	closing       bool
 }
 
-// NewServer creates a new SSE server.
-func NewServer(options *Options) *Server {
+// NewServer creates a new SSE server. ctx is the server-wide context: it is
+// the parent of every Client's context, so canceling it (or calling
+// Shutdown) disconnects every currently connected client. A nil ctx is
+// treated as context.Background().
+func NewServer(ctx context.Context, options *Options) *Server {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	if options == nil {
 		options = &Options{
 			Logger: log.New(os.Stdout, "go-sse: ", log.LstdFlags),
@@ -31,13 +52,30 @@ func NewServer(options *Options) *Server {
 		options.Logger = log.New(ioutil.Discard, "", log.LstdFlags)
 	}
 
+	if options.HistoryStore == nil && options.HistorySize > 0 {
+		options.HistoryStore = newMemoryHistoryStore(options.HistorySize, options.HistoryTTL)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
 	s := &Server{
 		options,
 		make(map[string]*Channel),
+		sync.RWMutex{},
 		make(chan *Client),
 		make(chan *Client),
 		make(chan bool),
 		make(chan string),
+		newNodeID(),
+		make(map[string]func()),
+		make(chan *BrokerMessage),
+		ctx,
+		cancel,
+		sync.WaitGroup{},
+		sync.Once{},
+		0,
+		sync.Mutex{},
+		false,
 	}
 
 	go s.dispatch()
@@ -62,6 +100,12 @@ func (s *Server) ServeHTTP(response http.ResponseWriter, request *http.Request)
 	}
 
 	if request.Method == "GET" {
+		if !s.register() {
+			http.Error(response, "Server is shutting down.", http.StatusServiceUnavailable)
+			return
+		}
+		defer s.wg.Done()
+
 		h.Set("Content-Type", "text/event-stream")
 		h.Set("Cache-Control", "no-cache")
 		h.Set("Connection", "keep-alive")
@@ -77,21 +121,51 @@ func (s *Server) ServeHTTP(response http.ResponseWriter, request *http.Request)
 		}
 
 		lastEventID := request.Header.Get("Last-Event-ID")
-		c := newClient(lastEventID, channelName, clientName, version)
+		c := newClient(s.ctx, lastEventID, channelName, clientName, version, s.options.ClientBufferSize, s.options.SlowClientPolicy, s.metrics())
+
+		response.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		// Registering with dispatch before looking at history, and having
+		// dispatch compute the Last-Event-ID replay itself as part of that
+		// same registration (see the addClient case), means the two can't
+		// race: any message already published completed before dispatch's
+		// lock was granted and is in the replay; anything published after
+		// sees c already registered and is delivered live through c.send.
+		// A message can never fall in a gap and be missed, nor land in
+		// both and be delivered twice.
 		s.addClient <- c
-		closeNotify := response.(http.CloseNotifier).CloseNotify()
 
+		if lastEventID != "" && s.options.HistoryStore != nil {
+			if replay := <-c.history; replay != nil {
+				if !replay.found {
+					fmt.Fprint(response, (&Message{event: "sync-lost"}).String())
+				} else {
+					for _, msg := range replay.messages {
+						msg.retry = s.options.RetryInterval
+						fmt.Fprint(response, msg.String())
+					}
+				}
+				flusher.Flush()
+			}
+		}
+
+		// Disconnects either when the request's own context is canceled
+		// (the client went away) or when the client's context is canceled
+		// directly (server-wide shutdown), whichever comes first.
 		go func() {
-			<-closeNotify
+			select {
+			case <-request.Context().Done():
+				c.cancel()
+			case <-c.ctx.Done():
+			}
+
 			s.removeClient <- c
-			if s.options.OnClientDisconnectFunc != nil {
-				s.options.OnClientDisconnectFunc(c.channel, c.name)
+			if s.options.OnClientDisconnect != nil {
+				s.options.OnClientDisconnect(c.channel, c.name)
 			}
 		}()
 
-		response.WriteHeader(http.StatusOK)
-		flusher.Flush()
-
 		for msg := range c.send {
 			msg.retry = s.options.RetryInterval
 			fmt.Fprintf(response, msg.String())
@@ -102,6 +176,25 @@ func (s *Server) ServeHTTP(response http.ResponseWriter, request *http.Request)
 	}
 }
 
+// register marks a ServeHTTP call as in flight, so Shutdown's wg.Wait
+// accounts for it, and returns false once Shutdown has started. Gating
+// wg.Add behind the same mutex Shutdown sets s.closing under (rather than
+// each independently checking s.ctx.Err()/calling wg.Add) avoids the
+// classic "late Add races Wait" bug: either this Add happens-before
+// Shutdown's first wg.Wait, or s.closing is already true and no Add
+// happens at all.
+func (s *Server) register() bool {
+	s.shutdownMu.Lock()
+	defer s.shutdownMu.Unlock()
+
+	if s.closing {
+		return false
+	}
+
+	s.wg.Add(1)
+	return true
+}
+
 // SendMessage broadcast a message to all clients in a channel.
 // If channel is an empty string, it will broadcast the message to all channels.
 func (s *Server) SendMessage(channel string, message *Message) {
@@ -117,14 +210,158 @@ func (s *Server) SendMessageToClient(channel string, client string, message *Mes
 			s.options.Logger.Print("broadcasting message to all channels.")
 		}
 
-		for _, ch := range s.channels {
-			ch.SendMessage(client, message)
+		s.channelsMu.RLock()
+		targets := make(map[string][]*Client, len(s.channels))
+		for name, ch := range s.channels {
+			targets[name] = ch.snapshotTargets(client, message)
+		}
+		s.channelsMu.RUnlock()
+
+		for name, clients := range targets {
+			for _, cl := range clients {
+				cl.SendMessage(message)
+			}
+			s.recordHistory(name, message)
+			s.publishToBroker(name, client, message)
+			s.notifyPublish(name, message)
 		}
-	} else if _, ok := s.channels[channel]; ok {
+		return
+	}
+
+	// Snapshotting the target clients under RLock and sending to them only
+	// after releasing it keeps a slow/stalled reader's blocking send from
+	// holding channelsMu: a pending Lock() (e.g. dispatch registering a new
+	// client) would otherwise block every subsequent RLock too, freezing
+	// HasChannel/GetChannel/new connections on every other channel as well.
+	s.channelsMu.RLock()
+	ch, ok := s.channels[channel]
+	var targets []*Client
+	if ok {
+		targets = ch.snapshotTargets(client, message)
+	}
+	s.channelsMu.RUnlock()
+
+	if ok {
 		s.options.Logger.Printf("message sent to channel '%s'.", channel)
-		s.channels[channel].SendMessage(client, message)
+		for _, cl := range targets {
+			cl.SendMessage(message)
+		}
 	} else {
-		s.options.Logger.Printf("message not sent because channel '%s' has no clients.", channel)
+		// The channel may still have subscribers on other nodes, and
+		// recordHistory below must still run, even though it has no
+		// clients connected locally.
+		s.options.Logger.Printf("message not sent locally because channel '%s' has no clients.", channel)
+	}
+
+	// recordHistory runs unconditionally, regardless of whether the channel
+	// has any clients connected locally, so a client that reconnects after
+	// the channel existed can still replay what was published while it had
+	// no local subscribers.
+	s.recordHistory(channel, message)
+	s.publishToBroker(channel, client, message)
+	s.notifyPublish(channel, message)
+}
+
+// Publish encodes v with the configured Codec (encoding/json by default)
+// and broadcasts it as the data of a message with the given event name,
+// so callers don't have to repeat marshal boilerplate at every publish
+// site. The message is given a server-generated id, so it's captured by
+// HistoryStore like any other message carrying one.
+func (s *Server) Publish(channel, event string, v interface{}) error {
+	codec := s.options.Codec
+	if codec == nil {
+		codec = jsonCodec{}
+	}
+
+	data, err := codec.Encode(v)
+	if err != nil {
+		return err
+	}
+
+	id := fmt.Sprintf("%s-%d", s.nodeID, atomic.AddInt64(&s.publishSeq, 1))
+	s.SendMessage(channel, NewMessage(id, string(data), event))
+	return nil
+}
+
+// notifyPublish records the published-messages metric for channel and
+// calls Options.OnMessagePublish, if set.
+func (s *Server) notifyPublish(channel string, message *Message) {
+	s.metrics().Counter("go_sse_messages_published_total", map[string]string{"channel": channel}).Inc()
+
+	if s.options.OnMessagePublish != nil {
+		s.options.OnMessagePublish(channel, message)
+	}
+}
+
+// recordHistory stores message in the configured HistoryStore, if any, so
+// it can be replayed to a client that reconnects with Last-Event-ID.
+//
+// It takes channelsMu's write lock for the duration of the store write,
+// the same lock dispatch's addClient case holds while looking up a newly
+// registering client's Last-Event-ID history. That makes the two mutually
+// exclusive: a publish either finishes recording before a registration's
+// lookup is granted the lock (so it's included in the replay) or starts
+// its lookup only once already registered (so it's delivered live
+// instead) — never both, and never neither.
+func (s *Server) recordHistory(channel string, message *Message) {
+	if s.options.HistoryStore == nil {
+		return
+	}
+
+	s.channelsMu.Lock()
+	defer s.channelsMu.Unlock()
+
+	s.options.HistoryStore.Add(channel, message)
+}
+
+// publishToBroker forwards a message published locally to the configured
+// Broker, if any, so other go-sse instances subscribed to channel can
+// re-broadcast it to their own clients.
+func (s *Server) publishToBroker(channel, client string, message *Message) {
+	if s.options.Broker == nil {
+		return
+	}
+
+	err := s.options.Broker.Publish(channel, &BrokerMessage{
+		NodeID:  s.nodeID,
+		Channel: channel,
+		Client:  client,
+		Message: message,
+	})
+	if err != nil {
+		s.options.Logger.Printf("failed to publish message to broker for channel '%s': %s", channel, err)
+	}
+}
+
+// subscribeToBroker subscribes to remote messages for channel, if a Broker
+// is configured, and records the stop function so it can be released when
+// the channel is closed locally.
+func (s *Server) subscribeToBroker(channel string) {
+	if s.options.Broker == nil {
+		return
+	}
+
+	stop, err := s.options.Broker.Subscribe(channel, func(msg *BrokerMessage) {
+		if msg.NodeID == s.nodeID {
+			// Already delivered locally when it was published.
+			return
+		}
+		s.remoteMessage <- msg
+	})
+	if err != nil {
+		s.options.Logger.Printf("failed to subscribe to broker for channel '%s': %s", channel, err)
+		return
+	}
+
+	s.brokerStop[channel] = stop
+}
+
+// unsubscribeFromBroker releases the broker subscription for channel, if
+// any.
+func (s *Server) unsubscribeFromBroker(channel string) {
+	if stop, ok := s.brokerStop[channel]; ok {
+		stop()
+		delete(s.brokerStop, channel)
 	}
 }
 
@@ -135,13 +372,51 @@ func (s *Server) Restart() {
 	s.close()
 }
 
-// Shutdown performs a graceful server shutdown.
-func (s *Server) Shutdown() {
-	s.shutdown <- true
+// Shutdown performs a graceful server shutdown, mirroring http.Server.Shutdown:
+// it stops accepting new clients, cancels every client's context, and waits
+// for each ServeHTTP call to drain its pending messages and return. It
+// returns early with ctx.Err() if ctx expires before that finishes.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var err error
+
+	s.closeOnce.Do(func() {
+		s.shutdownMu.Lock()
+		s.closing = true
+		s.shutdownMu.Unlock()
+
+		s.cancel()
+
+		done := make(chan struct{})
+		go func() {
+			s.wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		// s.shutdown tells dispatch to close addClient/removeClient, which
+		// races with any ServeHTTP goroutine still mid-send on them. Only
+		// safe once every client has actually drained, so if ctx expired
+		// first, keep waiting for done in the background and signal
+		// dispatch once it fires instead of doing it unconditionally here.
+		go func() {
+			<-done
+			s.shutdown <- true
+		}()
+	})
+
+	return err
 }
 
 // ClientCount returns the number of clients connected to this server.
 func (s *Server) ClientCount() int {
+	s.channelsMu.RLock()
+	defer s.channelsMu.RUnlock()
+
 	i := 0
 
 	for _, channel := range s.channels {
@@ -153,19 +428,28 @@ func (s *Server) ClientCount() int {
 
 // HasChannel returns true if the channel associated with name exists.
 func (s *Server) HasChannel(name string) bool {
+	s.channelsMu.RLock()
+	defer s.channelsMu.RUnlock()
+
 	_, ok := s.channels[name]
 	return ok
 }
 
 // GetChannel returns the channel associated with name or nil if not found.
 func (s *Server) GetChannel(name string) (*Channel, bool) {
+	s.channelsMu.RLock()
+	defer s.channelsMu.RUnlock()
+
 	ch, ok := s.channels[name]
 	return ch, ok
 }
 
 // Channels returns a list of all channels to the server.
 func (s *Server) Channels() []string {
-	channels := []string{}
+	s.channelsMu.RLock()
+	defer s.channelsMu.RUnlock()
+
+	channels := make([]string, 0, len(s.channels))
 
 	for name := range s.channels {
 		channels = append(channels, name)
@@ -180,7 +464,7 @@ func (s *Server) CloseChannel(name string) {
 }
 
 func (s *Server) close() {
-	for name := range s.channels {
+	for _, name := range s.Channels() {
 		s.closeChannel <- name
 	}
 }
@@ -193,46 +477,134 @@ func (s *Server) dispatch() {
 
 		// New client connected.
 		case c := <-s.addClient:
+			s.channelsMu.Lock()
 			ch, exists := s.channels[c.channel]
+			created := !exists
 
 			if !exists {
 				ch = newChannel(c.channel)
 				s.channels[ch.name] = ch
-
+				s.subscribeToBroker(ch.name)
+				s.metrics().Counter("go_sse_channels_created_total", nil).Inc()
 				s.options.Logger.Printf("channel '%s' created.", ch.name)
 			}
 
 			ch.addClient(c)
+			s.metrics().Gauge("go_sse_clients_connected", map[string]string{"channel": ch.name}).Set(float64(ch.ClientCount()))
+
+			// The Last-Event-ID lookup happens here, still under the write
+			// lock recordHistory itself takes before calling HistoryStore.Add,
+			// so it can't land in the gap between "publish recorded" and
+			// "client registered": any publish already recorded completed
+			// before this Lock was granted, hence is in the replay; any
+			// publish that acquires the lock after this one unlocks sees c
+			// already registered, hence is delivered live instead. A message
+			// can never be both missed and replayed, or delivered twice.
+			var replay *historyReplay
+			if c.lastEventID != "" && s.options.HistoryStore != nil {
+				messages, found := s.options.HistoryStore.Since(c.channel, c.lastEventID)
+				replay = &historyReplay{messages: messages, found: found}
+			}
+
+			s.channelsMu.Unlock()
 			s.options.Logger.Printf("new client connected to channel '%s'.", ch.name)
 
+			if replay != nil {
+				c.history <- replay
+			}
+
+			// Hooks run after Unlock: channelsMu is a non-reentrant
+			// RWMutex, and a hook calling back into HasChannel,
+			// SendMessage, etc. while it is held would deadlock
+			// dispatch permanently.
+			if created && s.options.OnChannelCreate != nil {
+				s.options.OnChannelCreate(ch.name)
+			}
+
+			if s.options.OnClientConnect != nil {
+				s.options.OnClientConnect(ch.name, c.name)
+			}
+
 		// Client disconnected.
 		case c := <-s.removeClient:
+			s.channelsMu.Lock()
+			var closedChannel string
 			if ch, exists := s.channels[c.channel]; exists {
 				ch.removeClient(c)
+				s.metrics().Gauge("go_sse_clients_connected", map[string]string{"channel": ch.name}).Set(float64(ch.ClientCount()))
 				s.options.Logger.Printf("client disconnected from channel '%s'.", ch.name)
 
 				s.options.Logger.Printf("checking if channel '%s' has clients.", ch.name)
 				if ch.ClientCount() == 0 {
 					delete(s.channels, ch.name)
 					ch.Close()
+					s.unsubscribeFromBroker(ch.name)
+					s.metrics().Counter("go_sse_channels_closed_total", nil).Inc()
+					closedChannel = ch.name
 
 					s.options.Logger.Printf("channel '%s' has no clients.", ch.name)
 				}
 			}
+			s.channelsMu.Unlock()
+
+			if closedChannel != "" && s.options.OnChannelClose != nil {
+				s.options.OnChannelClose(closedChannel)
+			}
 
 		// Close channel and all clients in it.
 		case channel := <-s.closeChannel:
+			s.channelsMu.Lock()
+			var closedChannel string
 			if ch, exists := s.channels[channel]; exists {
 				delete(s.channels, channel)
 				ch.Close()
+				s.unsubscribeFromBroker(channel)
+				s.metrics().Counter("go_sse_channels_closed_total", nil).Inc()
+				closedChannel = ch.name
 				s.options.Logger.Printf("channel '%s' closed.", ch.name)
 			} else {
 				s.options.Logger.Printf("requested to close channel '%s', but it doesn't exists.", channel)
 			}
+			s.channelsMu.Unlock()
+
+			if closedChannel != "" && s.options.OnChannelClose != nil {
+				s.options.OnChannelClose(closedChannel)
+			}
+
+		// Message received from another node through the Broker.
+		case msg := <-s.remoteMessage:
+			s.channelsMu.RLock()
+			var targets []*Client
+			if ch, exists := s.channels[msg.Channel]; exists {
+				targets = ch.snapshotTargets(msg.Client, msg.Message)
+			}
+			s.channelsMu.RUnlock()
+
+			for _, cl := range targets {
+				cl.SendMessage(msg.Message)
+			}
 
-		// Event Source shutdown.
+		// Event Source shutdown. Channels are closed directly here, rather
+		// than through s.closeChannel like Restart does, because dispatch
+		// is the sole reader of that channel and is the one blocked here.
 		case <-s.shutdown:
-			s.close()
+			s.channelsMu.Lock()
+			closedChannels := make([]string, 0, len(s.channels))
+			for name, ch := range s.channels {
+				ch.Close()
+				s.unsubscribeFromBroker(name)
+				delete(s.channels, name)
+				s.metrics().Counter("go_sse_channels_closed_total", nil).Inc()
+				closedChannels = append(closedChannels, name)
+			}
+			s.channelsMu.Unlock()
+
+			if s.options.OnChannelClose != nil {
+				for _, name := range closedChannels {
+					s.options.OnChannelClose(name)
+				}
+			}
+
 			close(s.addClient)
 			close(s.removeClient)
 			close(s.closeChannel)
@@ -242,7 +614,8 @@ func (s *Server) dispatch() {
 			return
 		case <-time.After(15 * time.Second):
 			if s.options.Heartbeat {
-				s.SendMessage("", "", &Message{event: "heartbeat"})
+				s.metrics().Counter("go_sse_heartbeats_total", nil).Inc()
+				s.SendMessage("", &Message{event: "heartbeat"})
 			}
 		}
 	}
@@ -0,0 +1,50 @@
+package sse
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestSendMessageToClientDoesNotBlockOtherChannels reproduces the freeze
+// review comment #1 described: a client with the default unbuffered send
+// channel that never reads leaves SendMessageToClient parked on a blocking
+// send. Previously that block happened while still holding channelsMu,
+// so a concurrent dispatch registration (which needs the writer lock)
+// would queue behind it, and a pending writer then blocks every
+// subsequent RLock too, freezing HasChannel/GetChannel for every other
+// channel. SendMessageToClient must release channelsMu before blocking.
+func TestSendMessageToClientDoesNotBlockOtherChannels(t *testing.T) {
+	s := NewServer(context.Background(), &Options{})
+	defer s.Shutdown(context.Background())
+
+	stalled := newClient(s.ctx, "", "stalled", "bob", 0, 0, Drop, noopMetrics{})
+	s.addClient <- stalled
+
+	sent := make(chan struct{})
+	go func() {
+		s.SendMessage("stalled", NewMessage("1", "hi", "greeting"))
+		close(sent)
+	}()
+
+	// Give the blocking send a moment to actually start.
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		s.addClient <- newClient(s.ctx, "", "other", "alice", 0, 0, Drop, noopMetrics{})
+		s.HasChannel("other")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("registering a client on an unrelated channel blocked while another client's send stalled")
+	}
+
+	// Drain the stalled client so its blocking send completes before
+	// Shutdown closes its channel, rather than racing it.
+	<-stalled.send
+	<-sent
+}